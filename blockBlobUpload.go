@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+)
+
+const (
+	defaultUploadChunkSize   = 4 * 1024 * 1024 // 4 MiB
+	defaultUploadParallelism = 4
+	maxPutBlockAttempts      = 3
+)
+
+// uploadFileAsBlockBlob streams srcPath to blobName inside cnt as a block blob,
+// splitting it into chunkSize blocks and uploading them concurrently with
+// parallelism workers before committing the block list in a single call.
+// progress, if non-nil, is invoked after every block upload with the number
+// of bytes uploaded so far and the total file size.
+func uploadFileAsBlockBlob(cnt storage.Container, blobName, srcPath string, chunkSize, parallelism int, progress func(uploaded, total int64)) error {
+	if chunkSize <= 0 {
+		chunkSize = defaultUploadChunkSize
+	}
+	if parallelism <= 0 {
+		parallelism = defaultUploadParallelism
+	}
+
+	f, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("open source file failed: %v", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat source file failed: %v", err)
+	}
+	totalSize := info.Size()
+
+	b := cnt.GetBlobReference(blobName)
+	err = b.CreateBlockBlob(nil)
+	if err != nil {
+		return fmt.Errorf("create block blob failed: %v", err)
+	}
+
+	type chunk struct {
+		index int
+		data  []byte
+	}
+	type result struct {
+		index int
+		size  int64
+		block storage.Block
+		err   error
+	}
+
+	chunks := make(chan chunk, parallelism)
+	results := make(chan result, parallelism)
+
+	var wg sync.WaitGroup
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range chunks {
+				id := blockID(c.index)
+				err := putBlockWithRetry(b, id, c.data, maxPutBlockAttempts)
+				results <- result{
+					index: c.index,
+					size:  int64(len(c.data)),
+					block: storage.Block{ID: id, Status: storage.BlockStatusUncommitted},
+					err:   err,
+				}
+			}
+		}()
+	}
+
+	var readErr error
+	go func() {
+		defer close(chunks)
+		buf := make([]byte, chunkSize)
+		for i := 0; ; i++ {
+			n, err := io.ReadFull(f, buf)
+			if n > 0 {
+				data := make([]byte, n)
+				copy(data, buf[:n])
+				chunks <- chunk{index: i, data: data}
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			if err != nil {
+				readErr = err
+				break
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	blocksByIndex := make(map[int]storage.Block)
+	var uploaded int64
+	var firstErr error
+	for r := range results {
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("put block %d failed: %v", r.index, r.err)
+			}
+			continue
+		}
+		blocksByIndex[r.index] = r.block
+		uploaded += r.size
+		if progress != nil {
+			progress(uploaded, totalSize)
+		}
+	}
+
+	if readErr != nil {
+		return fmt.Errorf("read source file failed: %v", readErr)
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+
+	// Blocks were produced out of order by the worker pool; reassemble them
+	// by chunk index. Sorting by ID would be wrong: base64's alphabet isn't
+	// ASCII-monotonic, so lexicographic ID order doesn't match numeric index
+	// order once there are enough blocks (e.g. block 40 sorts before block 0).
+	blocks := make([]storage.Block, len(blocksByIndex))
+	for index, block := range blocksByIndex {
+		blocks[index] = block
+	}
+
+	err = withRetry(context.Background(), defaultRetryPolicy, func() error {
+		return b.PutBlockList(blocks, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("put block list failed: %v", err)
+	}
+
+	return nil
+}
+
+// blockID returns a base64-encoded, fixed-width numeric block ID so that all
+// IDs committed to the same blob decode to the same length.
+func blockID(index int) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%010d", index)))
+}
+
+// putBlockWithRetry calls PutBlock under the sample's default retry policy,
+// with its attempt budget replaced by attempts.
+func putBlockWithRetry(b storage.Blob, id string, data []byte, attempts int) error {
+	policy := defaultRetryPolicy
+	policy.MaxAttempts = attempts
+	return withRetry(context.Background(), policy, func() error {
+		return b.PutBlock(id, data, nil)
+	})
+}
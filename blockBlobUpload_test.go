@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+)
+
+// TestUploadFileAsBlockBlobAgainstEmulator exercises uploadFileAsBlockBlob
+// end-to-end against the Azure Storage Emulator, the same way the rest of
+// this sample supports running with -emulator. It is skipped if the
+// emulator isn't reachable.
+func TestUploadFileAsBlockBlobAgainstEmulator(t *testing.T) {
+	client, err := storage.NewBasicClient(storage.StorageEmulatorAccountName, storage.StorageEmulatorAccountKey)
+	if err != nil {
+		t.Fatalf("create emulator client failed: %v", err)
+	}
+	cli := client.GetBlobService()
+
+	cnt := cli.GetContainerReference("uploadfileasblockblobtest")
+	if _, err := cnt.CreateIfNotExists(nil); err != nil {
+		t.Skipf("storage emulator not reachable, skipping integration test: %v", err)
+	}
+	defer cnt.Delete(nil)
+
+	srcPath, err := writeTempFile(3 * defaultUploadChunkSize / 2) // force more than one chunk
+	if err != nil {
+		t.Fatalf("create source file failed: %v", err)
+	}
+	defer os.Remove(srcPath)
+
+	var lastUploaded, total int64
+	progress := func(uploaded, totalBytes int64) {
+		lastUploaded = uploaded
+		total = totalBytes
+	}
+
+	err = uploadFileAsBlockBlob(cnt, "uploadedblob", srcPath, defaultUploadChunkSize, 2, progress)
+	if err != nil {
+		t.Fatalf("uploadFileAsBlockBlob failed: %v", err)
+	}
+
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		t.Fatalf("stat source file failed: %v", err)
+	}
+	if lastUploaded != info.Size() || total != info.Size() {
+		t.Fatalf("expected final progress %d/%d, got %d/%d", info.Size(), info.Size(), lastUploaded, total)
+	}
+
+	b := cnt.GetBlobReference("uploadedblob")
+	if err := b.GetProperties(nil); err != nil {
+		t.Fatalf("get uploaded blob properties failed: %v", err)
+	}
+	if b.Properties.ContentLength != info.Size() {
+		t.Fatalf("uploaded blob size %d does not match source file size %d", b.Properties.ContentLength, info.Size())
+	}
+}
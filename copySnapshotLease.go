@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+)
+
+const copyPollMaxInterval = 10 * time.Second
+
+// copyAndSnapshotSamples demonstrates the backup/coordination workflow
+// built from server-side copy, snapshots and leases: it leases srcBlobName,
+// snapshots it, starts an async server-side copy of that snapshot to a new
+// blob in the same container, polls the copy to completion, then breaks the
+// lease and deletes the snapshot.
+//
+// For more information, please visit: https://docs.microsoft.com/en-us/rest/api/storageservices/copy-blob
+func copyAndSnapshotSamples(cnt storage.Container, srcBlobName string) error {
+	src := cnt.GetBlobReference(srcBlobName)
+
+	fmt.Println("Acquire an infinite lease on the source blob...")
+	leaseID, err := src.AcquireLease(-1, "", nil)
+	if err != nil {
+		return fmt.Errorf("acquire lease failed: %v", err)
+	}
+	fmt.Printf("\tleased with lease ID %v\n", leaseID)
+
+	fmt.Println("Take a snapshot of the source blob...")
+	snapshotTime, err := src.CreateSnapshot(&storage.SnapshotOptions{
+		LeaseID: leaseID,
+	})
+	if err != nil {
+		breakLease(src)
+		return fmt.Errorf("create snapshot failed: %v", err)
+	}
+	fmt.Printf("\tsnapshot taken at %v\n", snapshotTime)
+
+	snapshot := src
+	snapshot.Snapshot = *snapshotTime
+
+	destName := srcBlobName + "-copy"
+	dest := cnt.GetBlobReference(destName)
+
+	fmt.Println("Start an async server-side copy from the snapshot...")
+	_, err = dest.StartCopy(snapshot.GetURL(), nil)
+	if err != nil {
+		breakLease(src)
+		return fmt.Errorf("start copy failed: %v", err)
+	}
+
+	err = pollCopyStatus(dest)
+	if err != nil {
+		breakLease(src)
+		return err
+	}
+
+	fmt.Println("Break the lease on the source blob...")
+	_, err = src.BreakLease(nil)
+	if err != nil {
+		return fmt.Errorf("break lease failed: %v", err)
+	}
+
+	fmt.Println("Delete the snapshot...")
+	err = src.Delete(&storage.DeleteBlobOptions{
+		DeleteSnapshots: storage.DeleteSnapshotsOnlyOption,
+	})
+	if err != nil {
+		return fmt.Errorf("delete snapshot failed: %v", err)
+	}
+
+	return nil
+}
+
+// pollCopyStatus polls dest's properties until Properties.CopyStatus
+// reports "success" or "failed", backing off exponentially between polls.
+func pollCopyStatus(dest storage.Blob) error {
+	fmt.Println("Poll copy status...")
+	interval := 500 * time.Millisecond
+	for {
+		err := withRetry(context.Background(), defaultRetryPolicy, func() error {
+			return dest.GetProperties(nil)
+		})
+		if err != nil {
+			return fmt.Errorf("get copy destination properties failed: %v", err)
+		}
+
+		status := dest.Properties.CopyStatus
+		fmt.Printf("\tcopy status: %v\n", status)
+
+		switch status {
+		case "success":
+			return nil
+		case "failed", "aborted":
+			return fmt.Errorf("copy ended with status %v: %v", status, dest.Properties.CopyStatusDescription)
+		}
+
+		time.Sleep(interval)
+		interval *= 2
+		if interval > copyPollMaxInterval {
+			interval = copyPollMaxInterval
+		}
+	}
+}
+
+// breakLease releases the source blob's lease during error cleanup,
+// logging rather than returning a failure so the original error surfaces.
+func breakLease(b storage.Blob) {
+	_, err := b.BreakLease(nil)
+	if err != nil {
+		fmt.Printf("\tcleanup: break lease failed: %v\n", err)
+	}
+}
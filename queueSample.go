@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+)
+
+// queueSamples creates a queue, exercises message enqueue/peek/dequeue/update,
+// and deletes the queue. main skips it when the -skip-queue flag is set, for
+// use with the emulator, which does not implement the full queue API.
+//
+// For more information, please visit: https://docs.microsoft.com/en-us/rest/api/storageservices/queue-service-rest-api
+func queueSamples(queueName string) error {
+	queueCli := queueCliFromBasicClient()
+
+	fmt.Println("Create a queue with metadata...")
+	q := queueCli.GetQueueReference(queueName)
+	q.Metadata = map[string]string{"purpose": "getting-started-sample"}
+	err := q.Create(nil)
+	if err != nil {
+		return fmt.Errorf("create queue failed: %v", err)
+	}
+
+	fmt.Println("Put messages on the queue...")
+	err = q.GetMessageReference("hello queue").Put(nil)
+	if err != nil {
+		return fmt.Errorf("put message failed: %v", err)
+	}
+
+	binaryPayload := base64.StdEncoding.EncodeToString(randomData(32))
+	err = q.GetMessageReference(binaryPayload).Put(nil)
+	if err != nil {
+		return fmt.Errorf("put binary message failed: %v", err)
+	}
+
+	fmt.Println("Peek messages without dequeuing...")
+	peeked, err := q.PeekMessages(&storage.PeekMessagesOptions{NumOfMessages: 2})
+	if err != nil {
+		return fmt.Errorf("peek messages failed: %v", err)
+	}
+	for _, m := range peeked {
+		fmt.Printf("\tpeeked message %v: %v\n", m.ID, m.Text)
+	}
+
+	fmt.Println("Get a message with a visibility timeout and update it...")
+	got, err := q.GetMessages(&storage.GetMessagesOptions{
+		NumOfMessages:     1,
+		VisibilityTimeout: 30,
+	})
+	if err != nil {
+		return fmt.Errorf("get messages failed: %v", err)
+	}
+	for i := range got {
+		m := &got[i]
+		m.Text = "updated: " + m.Text
+		err = m.Update(&storage.UpdateMessageOptions{VisibilityTimeout: 30})
+		if err != nil {
+			return fmt.Errorf("update message failed: %v", err)
+		}
+		fmt.Printf("\tupdated message %v\n", m.ID)
+
+		err = m.Delete(nil)
+		if err != nil {
+			return fmt.Errorf("delete message failed: %v", err)
+		}
+	}
+
+	fmt.Println("Delete queue...")
+	err = q.Delete(nil)
+	if err != nil {
+		return fmt.Errorf("delete queue failed: %v", err)
+	}
+
+	return nil
+}
+
+// queueCliFromBasicClient builds a QueueServiceClient from the same account
+// credentials used for blobCli.
+func queueCliFromBasicClient() storage.QueueServiceClient {
+	client, err := storage.NewBasicClient(accountName, accountKey)
+	onErrorFail(err, "Create client failed")
+	return client.GetQueueService()
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+)
+
+// RetryPolicy controls how withRetry backs off between attempts.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialDelay   time.Duration
+	MaxDelay       time.Duration
+	Multiplier     float64
+	JitterFraction float64
+}
+
+// defaultRetryPolicy is used by the sample's operations unless overridden.
+var defaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialDelay:   200 * time.Millisecond,
+	MaxDelay:       10 * time.Second,
+	Multiplier:     2,
+	JitterFraction: 0.2,
+}
+
+// withRetry runs op, retrying according to policy when op fails with a
+// transient network error or an AzureStorageServiceError carrying a 429, 500,
+// 502, 503 or 504 status code, and gives up once ctx is done or the attempt budget is
+// exhausted.
+//
+// storage.AzureStorageServiceError does not expose the response headers, so
+// this does not honor a Retry-After header; it backs off with jittered
+// exponential delay instead.
+func withRetry(ctx context.Context, policy RetryPolicy, op func() error) error {
+	delay := policy.InitialDelay
+
+	var err error
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		err = op()
+		if err == nil {
+			return nil
+		}
+		if !isRetryableError(err) || attempt == policy.MaxAttempts {
+			return err
+		}
+
+		select {
+		case <-time.After(addJitter(delay, policy.JitterFraction)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		delay = time.Duration(float64(delay) * policy.Multiplier)
+		if delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+	}
+
+	return err
+}
+
+// isRetryableError reports whether err represents a transient failure worth
+// retrying: a network error, or an AzureStorageServiceError with a 429, 500,
+// 502, 503 or 504 status code.
+func isRetryableError(err error) bool {
+	if netErr, ok := err.(net.Error); ok {
+		return netErr.Temporary() || netErr.Timeout()
+	}
+
+	if azErr, ok := err.(storage.AzureStorageServiceError); ok {
+		switch azErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+
+	return false
+}
+
+// addJitter returns delay adjusted by a random +/- fraction.
+func addJitter(delay time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return delay
+	}
+	jitter := float64(delay) * fraction
+	offset := (rand.Float64()*2 - 1) * jitter
+	return time.Duration(float64(delay) + offset)
+}
+
+const maxAppendRaceRetries = 5
+
+// safeAppendBlock appends data to b, resolving the race where a concurrent
+// writer committed a block between when the caller learned the blob's length
+// and when it issues AppendBlock. On an AppendPositionConditionNotMet (HTTP
+// 412) failure it re-fetches the blob's current length and retries with an
+// updated AppendPosition condition, making at most maxAppendRaceRetries
+// attempts in all.
+func safeAppendBlock(b storage.Blob, data []byte) error {
+	for attempt := 0; attempt < maxAppendRaceRetries; attempt++ {
+		err := b.GetProperties(nil)
+		if err != nil {
+			return err
+		}
+
+		position := uint(b.Properties.ContentLength)
+		err = b.AppendBlock(data, &storage.AppendBlockOptions{
+			AppendPosition: &position,
+		})
+		if err == nil {
+			return nil
+		}
+
+		if !isAppendPositionConflict(err) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("safeAppendBlock: gave up after %d attempts due to concurrent writers", maxAppendRaceRetries)
+}
+
+// isAppendPositionConflict reports whether err is the 412
+// AppendPositionConditionNotMet error returned when another writer appended
+// to the blob first.
+func isAppendPositionConflict(err error) bool {
+	azErr, ok := err.(storage.AzureStorageServiceError)
+	if !ok {
+		return false
+	}
+	return azErr.StatusCode == http.StatusPreconditionFailed &&
+		azErr.Code == "AppendPositionConditionNotMet"
+}
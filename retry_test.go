@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+)
+
+// TestWithRetrySucceedsAfterTransientErrors exercises withRetry against a
+// server that returns 503 twice before succeeding with 201, mirroring a
+// transient Azure Storage outage.
+func TestWithRetrySucceedsAfterTransientErrors(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	policy := RetryPolicy{
+		MaxAttempts:    5,
+		InitialDelay:   time.Millisecond,
+		MaxDelay:       5 * time.Millisecond,
+		Multiplier:     2,
+		JitterFraction: 0,
+	}
+
+	err := withRetry(context.Background(), policy, func() error {
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			return storage.AzureStorageServiceError{StatusCode: resp.StatusCode}
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("withRetry returned error: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 calls (2 failures + 1 success), got %d", calls)
+	}
+}
+
+// TestWithRetryGivesUpOnNonRetryableError checks that a non-transient error
+// (e.g. 404) is returned immediately without consuming the retry budget.
+func TestWithRetryGivesUpOnNonRetryableError(t *testing.T) {
+	var calls int
+	err := withRetry(context.Background(), defaultRetryPolicy, func() error {
+		calls++
+		return storage.AzureStorageServiceError{StatusCode: http.StatusNotFound}
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if calls != 1 {
+		t.Fatalf("expected exactly 1 call for a non-retryable error, got %d", calls)
+	}
+}
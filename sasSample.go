@@ -0,0 +1,178 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SASParams describes the fields that make up the canonicalized
+// string-to-sign for a service-level (blob or container) SAS, as defined by
+// https://docs.microsoft.com/en-us/rest/api/storageservices/create-service-sas
+type SASParams struct {
+	Permissions           string // e.g. "r", "rw", "rwd"
+	Start                 time.Time
+	Expiry                time.Time
+	CanonicalizedResource string // e.g. "/blob/<account>/<container>/<blob>"
+	Resource              string // signed resource: "b" for blob, "c" for container
+	IPRange               string // optional, e.g. "168.1.5.60-168.1.5.70"
+	Protocol              string // optional, "https" or "https,http"
+	Version               string // e.g. "2018-03-28"
+}
+
+// signServiceSAS builds the canonical string-to-sign for params and signs it
+// with accountKey using HMAC-SHA256, returning the base64-encoded signature
+// for the "sig" query parameter (not yet URL-encoded).
+//
+// The field layout below matches the "2015-04-05 and later" string-to-sign
+// used by sv=2018-03-28; it predates the signedSnapshotTime (2018-11-09) and
+// signedEncryptionScope (2020-12-06) fields, so those are not included.
+func signServiceSAS(accountName, accountKey string, params SASParams) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(accountKey)
+	if err != nil {
+		return "", fmt.Errorf("decode account key failed: %v", err)
+	}
+
+	canonicalizedResource := params.CanonicalizedResource
+
+	stringToSign := strings.Join([]string{
+		params.Permissions,
+		formatSASTime(params.Start),
+		formatSASTime(params.Expiry),
+		canonicalizedResource,
+		"", // signed identifier
+		params.IPRange,
+		params.Protocol,
+		params.Version,
+		params.Resource,
+		"", // cache control
+		"", // content disposition
+		"", // content encoding
+		"", // content language
+		"", // content type
+	}, "\n")
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// formatSASTime renders t in the ISO 8601 form the SAS string-to-sign
+// expects, or "" when t is the zero value.
+func formatSASTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// buildBlobSASURL composes a full SAS URL for a blob from its signature and
+// query parameters.
+func buildBlobSASURL(accountName, containerName, blobName string, params SASParams, signature string) string {
+	values := url.Values{}
+	values.Set("sv", params.Version)
+	values.Set("sp", params.Permissions)
+	if !params.Start.IsZero() {
+		values.Set("st", formatSASTime(params.Start))
+	}
+	values.Set("se", formatSASTime(params.Expiry))
+	values.Set("sr", "b")
+	if params.IPRange != "" {
+		values.Set("sip", params.IPRange)
+	}
+	if params.Protocol != "" {
+		values.Set("spr", params.Protocol)
+	}
+	values.Set("sig", signature)
+
+	return fmt.Sprintf("https://%s.blob.core.windows.net/%s/%s?%s", accountName, containerName, blobName, values.Encode())
+}
+
+// sasSamples demonstrates generating and consuming a Shared Access Signature
+// for blobName inside containerName: a read-only SAS that succeeds a GET and
+// is rejected on PUT, and a container-level SAS used to list blobs with a
+// raw HTTP call.
+//
+// For more information, please visit: https://docs.microsoft.com/en-us/azure/storage/common/storage-sas-overview
+func sasSamples(containerName, blobName string) error {
+	const sasVersion = "2018-03-28"
+	now := time.Now()
+
+	fmt.Println("Generate a read-only SAS for the block blob...")
+	readParams := SASParams{
+		Permissions:           "r",
+		Start:                 now.Add(-5 * time.Minute),
+		Expiry:                now.Add(1 * time.Hour),
+		CanonicalizedResource: fmt.Sprintf("/blob/%s/%s/%s", accountName, containerName, blobName),
+		Resource:              "b",
+		Protocol:              "https",
+		Version:               sasVersion,
+	}
+	sig, err := signServiceSAS(accountName, accountKey, readParams)
+	if err != nil {
+		return fmt.Errorf("sign read SAS failed: %v", err)
+	}
+	readOnlyURL := buildBlobSASURL(accountName, containerName, blobName, readParams, sig)
+
+	fmt.Println("Read the blob anonymously through the SAS URL...")
+	client := &http.Client{}
+	resp, err := client.Get(readOnlyURL)
+	if err != nil {
+		return fmt.Errorf("anonymous GET via SAS failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	fmt.Printf("\tGET status %v, %d bytes\n", resp.Status, len(body))
+
+	fmt.Println("Attempt a write with the read-only SAS, expect it to be rejected...")
+	req, err := http.NewRequest(http.MethodPut, readOnlyURL, strings.NewReader("should be rejected"))
+	if err != nil {
+		return fmt.Errorf("build PUT request failed: %v", err)
+	}
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	resp, err = client.Do(req)
+	if err != nil {
+		return fmt.Errorf("anonymous PUT via SAS failed: %v", err)
+	}
+	defer resp.Body.Close()
+	fmt.Printf("\tPUT status %v (expected 403 Forbidden)\n", resp.Status)
+
+	fmt.Println("Generate a container-level SAS and list blobs with a raw HTTP call...")
+	listParams := SASParams{
+		Permissions:           "rl",
+		Expiry:                now.Add(1 * time.Hour),
+		CanonicalizedResource: fmt.Sprintf("/blob/%s/%s", accountName, containerName),
+		Resource:              "c",
+		Protocol:              "https",
+		Version:               sasVersion,
+	}
+	listSig, err := signServiceSAS(accountName, accountKey, listParams)
+	if err != nil {
+		return fmt.Errorf("sign container SAS failed: %v", err)
+	}
+	values := url.Values{}
+	values.Set("sv", sasVersion)
+	values.Set("sp", listParams.Permissions)
+	values.Set("se", formatSASTime(listParams.Expiry))
+	values.Set("sr", "c")
+	values.Set("spr", listParams.Protocol)
+	values.Set("restype", "container")
+	values.Set("comp", "list")
+	values.Set("sig", listSig)
+	listURL := fmt.Sprintf("https://%s.blob.core.windows.net/%s?%s", accountName, containerName, values.Encode())
+
+	resp, err = client.Get(listURL)
+	if err != nil {
+		return fmt.Errorf("list blobs via container SAS failed: %v", err)
+	}
+	defer resp.Body.Close()
+	fmt.Printf("\tlist status %v\n", resp.Status)
+
+	return nil
+}
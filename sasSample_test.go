@@ -0,0 +1,105 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+// expectedStringToSign reproduces the canonical "2015-04-05 and later"
+// service SAS string-to-sign layout from
+// https://docs.microsoft.com/en-us/rest/api/storageservices/create-service-sas
+// independently of signServiceSAS, so the test catches regressions in field
+// order/content rather than just re-running the same code under test.
+func expectedStringToSign(params SASParams) string {
+	return strings.Join([]string{
+		params.Permissions,
+		formatSASTime(params.Start),
+		formatSASTime(params.Expiry),
+		params.CanonicalizedResource,
+		"",
+		params.IPRange,
+		params.Protocol,
+		params.Version,
+		params.Resource,
+		"",
+		"",
+		"",
+		"",
+		"",
+	}, "\n")
+}
+
+func TestSignServiceSASMatchesCanonicalStringToSign(t *testing.T) {
+	accountKey := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	key, err := base64.StdEncoding.DecodeString(accountKey)
+	if err != nil {
+		t.Fatalf("decode test account key failed: %v", err)
+	}
+
+	cases := []SASParams{
+		{
+			Permissions:           "r",
+			Expiry:                time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			CanonicalizedResource: "/blob/myaccount/mycontainer/myblob",
+			Resource:              "b",
+			Protocol:              "https",
+			Version:               "2018-03-28",
+		},
+		{
+			Permissions:           "rl",
+			Expiry:                time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			CanonicalizedResource: "/blob/myaccount/mycontainer",
+			Resource:              "c",
+			Protocol:              "https",
+			Version:               "2018-03-28",
+		},
+	}
+
+	for _, params := range cases {
+		got, err := signServiceSAS("myaccount", accountKey, params)
+		if err != nil {
+			t.Fatalf("signServiceSAS failed: %v", err)
+		}
+
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(expectedStringToSign(params)))
+		want := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+		if got != want {
+			t.Errorf("signature for resource %q: got %v, want %v", params.Resource, got, want)
+		}
+	}
+}
+
+func TestSignServiceSASDiffersByResource(t *testing.T) {
+	accountKey := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	base := SASParams{
+		Permissions:           "r",
+		Expiry:                time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		CanonicalizedResource: "/blob/myaccount/mycontainer",
+		Protocol:              "https",
+		Version:               "2018-03-28",
+	}
+
+	blobParams := base
+	blobParams.Resource = "b"
+	containerParams := base
+	containerParams.Resource = "c"
+
+	blobSig, err := signServiceSAS("myaccount", accountKey, blobParams)
+	if err != nil {
+		t.Fatalf("signServiceSAS (blob) failed: %v", err)
+	}
+	containerSig, err := signServiceSAS("myaccount", accountKey, containerParams)
+	if err != nil {
+		t.Fatalf("signServiceSAS (container) failed: %v", err)
+	}
+
+	if blobSig == containerSig {
+		t.Fatal("expected different signatures for blob vs container signed-resource")
+	}
+}
@@ -3,9 +3,11 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/rand"
 	"os"
@@ -18,6 +20,7 @@ var (
 	accountKey  string
 	blobCli     storage.BlobStorageClient
 	emulator    *bool
+	skipQueue   *bool
 
 	appendBlobFile = "appendBlob.txt"
 	blockBlobFile  = "blockBlob.txt"
@@ -26,6 +29,7 @@ var (
 
 func init() {
 	emulator = flag.Bool("emulator", false, "use the Azure Storage Emulator")
+	skipQueue = flag.Bool("skip-queue", false, "skip the queue sample (the emulator does not implement the full queue API)")
 	flag.Parse()
 	if *emulator {
 		accountName = storage.StorageEmulatorAccountName
@@ -43,6 +47,12 @@ func init() {
 func main() {
 	fmt.Println("Azure Storage Blob Sample")
 	blobSamples("demoblobconatiner", "demoPageBlob", "demoAppendBlob", "demoBlockBlob")
+
+	if !*skipQueue {
+		fmt.Println("Azure Storage Queue Sample")
+		err := queueSamples("demoqueue")
+		onErrorFail(err, "Queue samples failed")
+	}
 }
 
 // blobSamples creates a container, and performs operations with page blobs, append blobs and block blobs.
@@ -75,6 +85,19 @@ func blobSamples(containerName, pageBlobName, appendBlobName, blockBlobName stri
 	err = printBlobList(cnt)
 	onErrorFail(err, "List blobs failed")
 
+	// The SAS sample issues requests straight to
+	// https://<account>.blob.core.windows.net, bypassing blobCli, so it
+	// cannot be pointed at the emulator.
+	if !*emulator {
+		fmt.Println("Azure Storage SAS Sample")
+		err = sasSamples(containerName, blockBlobName)
+		onErrorFail(err, "SAS samples failed")
+
+		fmt.Println("Azure Storage Copy, Snapshot and Lease Sample")
+		err = copyAndSnapshotSamples(cnt, blockBlobName)
+		onErrorFail(err, "Copy and snapshot samples failed")
+	}
+
 	fmt.Print("Press enter to delete the blobs, container and local files created in this sample...")
 
 	var input string
@@ -98,14 +121,16 @@ func appendBlobOperations(cnt storage.Container, appendBlobName string) error {
 	fmt.Println("Create an empty append blob...")
 	b := cnt.GetBlobReference(appendBlobName)
 	b.Properties.ContentType = "text/plain"
-	err := b.PutAppendBlob(nil)
+	err := withRetry(context.Background(), defaultRetryPolicy, func() error {
+		return b.PutAppendBlob(nil)
+	})
 	if err != nil {
 		return fmt.Errorf("put append blob failed: %v", err)
 	}
 
 	fmt.Println("Append a block to the blob...")
 	data := randomData(42) //Append blocks can have any length.
-	err = b.AppendBlock(data, nil)
+	err = safeAppendBlock(b, data)
 	if err != nil {
 		return fmt.Errorf("append block failed: %v", err)
 	}
@@ -133,7 +158,9 @@ func blockBlobOperations(cnt storage.Container, blockBlobName string) error {
 	fmt.Println("Put a block...")
 	blockID := base64.StdEncoding.EncodeToString([]byte("00000"))
 	data := randomData(1984)
-	err = b.PutBlock(blockID, data, nil)
+	err = withRetry(context.Background(), defaultRetryPolicy, func() error {
+		return b.PutBlock(blockID, data, nil)
+	})
 	if err != nil {
 		return fmt.Errorf("put block failed: %v", err)
 	}
@@ -144,7 +171,12 @@ func blockBlobOperations(cnt storage.Container, blockBlobName string) error {
 	}
 
 	fmt.Println("Get uncommitted blocks list...")
-	list, err := b.GetBlockList(storage.BlockListTypeUncommitted, nil)
+	var list *storage.BlockListResponse
+	err = withRetry(context.Background(), defaultRetryPolicy, func() error {
+		var getErr error
+		list, getErr = b.GetBlockList(storage.BlockListTypeUncommitted, nil)
+		return getErr
+	})
 	if err != nil {
 		return fmt.Errorf("get block list failed: %v", err)
 	}
@@ -155,7 +187,9 @@ func blockBlobOperations(cnt storage.Container, blockBlobName string) error {
 	}
 
 	fmt.Println("Commit blocks...")
-	err = b.PutBlockList(uncommittedBlocksList, nil)
+	err = withRetry(context.Background(), defaultRetryPolicy, func() error {
+		return b.PutBlockList(uncommittedBlocksList, nil)
+	})
 	if err != nil {
 		return fmt.Errorf("put block list failed: %v", err)
 	}
@@ -165,14 +199,46 @@ func blockBlobOperations(cnt storage.Container, blockBlobName string) error {
 		return fmt.Errorf("get block list failed: %v", err)
 	}
 
-	err = downloadBlob(b, blockBlobFile)
+	fmt.Printf("Download blob '%v' into '%v'...\n", b.Name, blockBlobFile)
+	err = streamDownload(b, blockBlobFile, defaultDownloadChunkSize, defaultDownloadParallelism)
 	if err != nil {
 		return fmt.Errorf("download blob failed: %v", err)
 	}
 
+	fmt.Println("Upload a larger local file as a block blob, in parallel chunks...")
+	srcPath, err := writeTempFile(8 * 1024 * 1024)
+	if err != nil {
+		return fmt.Errorf("create temp file failed: %v", err)
+	}
+	defer os.Remove(srcPath)
+
+	progress := func(uploaded, total int64) {
+		fmt.Printf("\t%v/%v bytes uploaded\n", uploaded, total)
+	}
+	err = uploadFileAsBlockBlob(cnt, blockBlobName+"-large", srcPath, defaultUploadChunkSize, defaultUploadParallelism, progress)
+	if err != nil {
+		return fmt.Errorf("upload file as block blob failed: %v", err)
+	}
+
 	return nil
 }
 
+// writeTempFile creates a local file of the given size filled with random
+// data, for use as the source of a chunked upload demo.
+func writeTempFile(size int) (string, error) {
+	f, err := ioutil.TempFile("", "blockblobupload")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(randomData(size)); err != nil {
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
 // pageBlobOperations performs simple page blob operations.
 // For more information, please visit: https://docs.microsoft.com/en-us/rest/api/storageservices/operations-on-page-blobs
 func pageBlobOperations(cnt storage.Container, pageBlobName string) error {
@@ -193,13 +259,20 @@ func pageBlobOperations(cnt storage.Container, pageBlobName string) error {
 	br := storage.BlobRange{
 		End: uint64(pageLen - 1),
 	}
-	err = b.WriteRange(br, bytes.NewReader(data), nil)
+	err = withRetry(context.Background(), defaultRetryPolicy, func() error {
+		return b.WriteRange(br, bytes.NewReader(data), nil) // fresh reader each attempt
+	})
 	if err != nil {
 		return fmt.Errorf("write range failed: %v", err)
 	}
 
 	fmt.Println("Get valid page ranges...")
-	pageRanges, err := b.GetPageRanges(nil)
+	var pageRanges *storage.GetPageRangesResponse
+	err = withRetry(context.Background(), defaultRetryPolicy, func() error {
+		var getErr error
+		pageRanges, getErr = b.GetPageRanges(nil)
+		return getErr
+	})
 	if err != nil {
 		return fmt.Errorf("get page ranges failed: %v", err)
 	}
@@ -208,7 +281,8 @@ func pageBlobOperations(cnt storage.Container, pageBlobName string) error {
 		fmt.Printf("\tFrom page %v to page %v\n", pageRange.Start, pageRange.End)
 	}
 
-	err = downloadBlob(b, pageBlobFile)
+	fmt.Printf("Download blob '%v' into '%v'...\n", b.Name, pageBlobFile)
+	err = streamDownload(b, pageBlobFile, defaultDownloadChunkSize, defaultDownloadParallelism)
 	if err != nil {
 		return fmt.Errorf("download blob failed: %v", err)
 	}
@@ -238,11 +312,16 @@ func downloadBlob(b storage.Blob, fileName string) error {
 		return fmt.Errorf("file '%v' already exists", fileName)
 	}
 
-	readCloser, err := b.Get(nil)
-	defer readCloser.Close()
+	var readCloser io.ReadCloser
+	err = withRetry(context.Background(), defaultRetryPolicy, func() error {
+		var getErr error
+		readCloser, getErr = b.Get(nil)
+		return getErr
+	})
 	if err != nil {
 		return fmt.Errorf("get blob failed: %v", err)
 	}
+	defer readCloser.Close()
 
 	bytesRead, err := ioutil.ReadAll(readCloser)
 	if err != nil {
@@ -260,7 +339,12 @@ func downloadBlob(b storage.Blob, fileName string) error {
 // printBlockList prints both committed and uncommitted blocks on a block blob.
 func printBlockList(b storage.Blob) error {
 	fmt.Println("Get block list...")
-	list, err := b.GetBlockList(storage.BlockListTypeAll, nil)
+	var list *storage.BlockListResponse
+	err := withRetry(context.Background(), defaultRetryPolicy, func() error {
+		var getErr error
+		list, getErr = b.GetBlockList(storage.BlockListTypeAll, nil)
+		return getErr
+	})
 	if err != nil {
 		return err
 	}